@@ -1,11 +1,17 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
 )
 
 // PredictionContract provides functions for managing predictions
@@ -48,7 +54,10 @@ type ModelInfo struct {
 	Mode      string `json:"mode"`
 }
 
-// PredictionData represents the complete prediction data structure
+// PredictionData represents the complete prediction data structure, as
+// accepted from and returned to callers. Internally it is split across
+// world state (PredictionPublic) and a private data collection
+// (PredictionPrivate) - see atmLocationsPDC below.
 type PredictionData struct {
 	CaseID          string          `json:"caseId"`
 	Top3ATMLocations []ATMLocation  `json:"top3AtmLocations"`
@@ -56,10 +65,522 @@ type PredictionData struct {
 	TimeWindow      TimeWindow      `json:"timeWindow"`
 	Timestamp       string          `json:"timestamp"`
 	ModelInfo       ModelInfo       `json:"model_info"`
+	EvidenceLinks   []EvidenceLink  `json:"evidenceLinks,omitempty"`
+}
+
+// atmLocationsPDC is the private data collection holding the sensitive
+// portion of a prediction (exact ATM locations, time window, per-alternative
+// confidences). It must be defined in the channel's collections_config.json
+// for the orgs that are allowed to see it (e.g. InvestigatorMSP, AnalystMSP).
+const atmLocationsPDC = "atmLocationsPDC"
+
+// PredictionPublic is the non-sensitive portion of a prediction, readable by
+// every org on the channel via world state. SensitiveDataHash is the
+// SHA-256 hash (hex-encoded) of the canonical JSON encoding of the matching
+// PredictionPrivate record, letting any org verify - without reading the
+// private payload itself - that the private data on file hasn't been
+// tampered with. See VerifyPredictionIntegrity.
+type PredictionPublic struct {
+	CaseID            string         `json:"caseId"`
+	Timestamp         string         `json:"timestamp"`
+	ModelInfo         ModelInfo      `json:"model_info"`
+	OverallConfidence float64        `json:"overallConfidence"`
+	Ranks             []int          `json:"ranks"`
+	SensitiveDataHash string         `json:"sensitiveDataHash"`
+	EvidenceLinks     []EvidenceLink `json:"evidenceLinks,omitempty"`
+}
+
+// EvidenceLink records that a piece of evidence (tracked by another
+// chaincode) has been associated with a case's prediction, so the full
+// chain of custody is visible from a single GetPrediction call. EvidenceHash
+// is whatever content-addressed reference the evidence-tracking chaincode
+// uses; this contract doesn't interpret it.
+type EvidenceLink struct {
+	EvidenceHash string `json:"evidenceHash"`
+	LinkedBy     string `json:"linkedBy"`
+	LinkedAt     string `json:"linkedAt"`
+}
+
+// PredictionPrivate is the sensitive portion of a prediction, stored only in
+// atmLocationsPDC and readable solely by orgs that are members of that
+// collection.
+type PredictionPrivate struct {
+	CaseID                 string        `json:"caseId"`
+	Top3ATMLocations       []ATMLocation `json:"top3AtmLocations"`
+	TimeWindow             TimeWindow    `json:"timeWindow"`
+	PrimaryConfidence      float64       `json:"primaryConfidence"`
+	AlternativeConfidences []float64     `json:"alternativeConfidences"`
+}
+
+// splitPrediction derives the public/private records that StorePrediction
+// and UpdatePrediction persist from a caller-supplied PredictionData.
+func splitPrediction(prediction PredictionData) (PredictionPublic, PredictionPrivate, error) {
+	private := PredictionPrivate{
+		CaseID:                 prediction.CaseID,
+		Top3ATMLocations:       prediction.Top3ATMLocations,
+		TimeWindow:             prediction.TimeWindow,
+		PrimaryConfidence:      prediction.ConfidenceScores.Primary,
+		AlternativeConfidences: prediction.ConfidenceScores.Alternatives,
+	}
+
+	hash, err := hashPrivatePrediction(private)
+	if err != nil {
+		return PredictionPublic{}, PredictionPrivate{}, err
+	}
+
+	ranks := make([]int, 0, len(prediction.Top3ATMLocations))
+	for _, atm := range prediction.Top3ATMLocations {
+		ranks = append(ranks, atm.Rank)
+	}
+
+	public := PredictionPublic{
+		CaseID:            prediction.CaseID,
+		Timestamp:         prediction.Timestamp,
+		ModelInfo:         prediction.ModelInfo,
+		OverallConfidence: prediction.ConfidenceScores.Overall,
+		Ranks:             ranks,
+		SensitiveDataHash: hash,
+	}
+
+	return public, private, nil
+}
+
+// mergePrediction reassembles the caller-facing PredictionData from its
+// public and private halves. private may be the zero value when the caller
+// isn't authorized to read atmLocationsPDC, in which case the sensitive
+// fields are simply left empty.
+func mergePrediction(public PredictionPublic, private PredictionPrivate) PredictionData {
+	return PredictionData{
+		CaseID:           public.CaseID,
+		Top3ATMLocations: private.Top3ATMLocations,
+		ConfidenceScores: ConfidenceScores{
+			Primary:      private.PrimaryConfidence,
+			Alternatives: private.AlternativeConfidences,
+			Overall:      public.OverallConfidence,
+		},
+		TimeWindow:    private.TimeWindow,
+		Timestamp:     public.Timestamp,
+		ModelInfo:     public.ModelInfo,
+		EvidenceLinks: public.EvidenceLinks,
+	}
+}
+
+// hashPrivatePrediction computes the SHA-256 hash, hex-encoded, of the
+// canonical JSON encoding of a PredictionPrivate record.
+func hashPrivatePrediction(private PredictionPrivate) (string, error) {
+	privateBytes, err := json.Marshal(private)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal private prediction data: %v", err)
+	}
+
+	sum := sha256.Sum256(privateBytes)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Secondary-index object types, passed to CreateCompositeKey. Date-range
+// lookups go through the CouchDB rich query in QueryPredictionsByDateRange
+// instead of a composite-key index, since world state's key range already
+// sorts under the U+0000 prefix that composite keys occupy - mixing plain
+// data keys and index keys in the same namespace makes range scans like
+// GetAllPredictions see (and have to skip) index entries. bankCityIndex and
+// topATMIndex are built from rank-1 ATM fields that only live in
+// atmLocationsPDC, so those composite keys are stored as private data in the
+// same collection rather than in world state - otherwise the index itself
+// would leak the sensitive values it's built from.
+const (
+	bankCityIndex = "bank~city~caseId"
+	topATMIndex   = "topAtmId~caseId"
+)
+
+// rankOneATM returns the rank-1 entry of a Top3ATMLocations slice, falling
+// back to the first entry if none is marked rank 1 (same as topATMEvent), or
+// the zero value if the slice is empty.
+func rankOneATM(locations []ATMLocation) ATMLocation {
+	for _, atm := range locations {
+		if atm.Rank == 1 {
+			return atm
+		}
+	}
+
+	if len(locations) > 0 {
+		return locations[0]
+	}
+
+	return ATMLocation{}
+}
+
+// putSecondaryIndexes creates the bank~city~caseId / topAtmId~caseId
+// private-data index entries for a prediction. Index entries are
+// empty-value markers keyed by the composite key; callers
+// range/partial-query over the composite key, never read the value.
+func putSecondaryIndexes(ctx contractapi.TransactionContextInterface, public PredictionPublic, private PredictionPrivate) error {
+	top := rankOneATM(private.Top3ATMLocations)
+
+	bankCityKey, err := ctx.GetStub().CreateCompositeKey(bankCityIndex, []string{top.Bank, top.City, public.CaseID})
+	if err != nil {
+		return fmt.Errorf("failed to build %s index key: %v", bankCityIndex, err)
+	}
+
+	if err := ctx.GetStub().PutPrivateData(atmLocationsPDC, bankCityKey, []byte{0x00}); err != nil {
+		return fmt.Errorf("failed to write %s index: %v", bankCityIndex, err)
+	}
+
+	atmKey, err := ctx.GetStub().CreateCompositeKey(topATMIndex, []string{top.ATMID, public.CaseID})
+	if err != nil {
+		return fmt.Errorf("failed to build %s index key: %v", topATMIndex, err)
+	}
+
+	if err := ctx.GetStub().PutPrivateData(atmLocationsPDC, atmKey, []byte{0x00}); err != nil {
+		return fmt.Errorf("failed to write %s index: %v", topATMIndex, err)
+	}
+
+	return nil
+}
+
+// deleteSecondaryIndexes removes the index entries previously written by
+// putSecondaryIndexes for an old version of a prediction. UpdatePrediction
+// calls this before putSecondaryIndexes so a changed bank/city/ATM doesn't
+// leave a stale entry alongside the new one.
+func deleteSecondaryIndexes(ctx contractapi.TransactionContextInterface, public PredictionPublic, private PredictionPrivate) error {
+	top := rankOneATM(private.Top3ATMLocations)
+
+	bankCityKey, err := ctx.GetStub().CreateCompositeKey(bankCityIndex, []string{top.Bank, top.City, public.CaseID})
+	if err != nil {
+		return fmt.Errorf("failed to build %s index key: %v", bankCityIndex, err)
+	}
+
+	if err := ctx.GetStub().DelPrivateData(atmLocationsPDC, bankCityKey); err != nil {
+		return fmt.Errorf("failed to delete %s index: %v", bankCityIndex, err)
+	}
+
+	atmKey, err := ctx.GetStub().CreateCompositeKey(topATMIndex, []string{top.ATMID, public.CaseID})
+	if err != nil {
+		return fmt.Errorf("failed to build %s index key: %v", topATMIndex, err)
+	}
+
+	if err := ctx.GetStub().DelPrivateData(atmLocationsPDC, atmKey); err != nil {
+		return fmt.Errorf("failed to delete %s index: %v", topATMIndex, err)
+	}
+
+	return nil
+}
+
+// Event names emitted on ctx.GetStub().SetEvent. Off-chain listeners should
+// switch on chaincode event Name, not on payload shape, since the payload
+// schema can grow independently per event.
+const (
+	EventPredictionStored      = "PredictionStored"
+	EventPredictionUpdated     = "PredictionUpdated"
+	EventPredictionInvalidated = "PredictionInvalidated"
+
+	// EventPredictionsBatchStored/EventPredictionsBatchUpserted are emitted
+	// once per StorePredictionsBatch/UpsertPredictionsBatch call instead of
+	// per item - Fabric keeps only the last SetEvent call's payload per
+	// transaction, so per-item PredictionStored/PredictionUpdated events
+	// emitted while a batch loop runs would otherwise all but the final one
+	// be silently discarded. See PredictionsBatchEvent.
+	EventPredictionsBatchStored   = "PredictionsBatchStored"
+	EventPredictionsBatchUpserted = "PredictionsBatchUpserted"
+)
+
+// PredictionEvent is the common payload shape for the single-case prediction
+// lifecycle events; the batch events use PredictionsBatchEvent instead.
+// TopATMID/Confidence reflect the rank-1 entry of Top3ATMLocations at
+// the time the event was emitted, so dashboards can render a headline result
+// without re-fetching the full prediction.
+type PredictionEvent struct {
+	CaseID     string  `json:"caseId"`
+	TopATMID   string  `json:"atm_id"`
+	Confidence float64 `json:"confidence"`
+	Timestamp  string  `json:"timestamp"`
+}
+
+// topATMEvent builds the common event payload from a stored prediction,
+// pulling the rank-1 ATM out of Top3ATMLocations if present.
+func topATMEvent(prediction PredictionData) PredictionEvent {
+	event := PredictionEvent{
+		CaseID:    prediction.CaseID,
+		Timestamp: prediction.Timestamp,
+	}
+
+	for _, atm := range prediction.Top3ATMLocations {
+		if atm.Rank == 1 {
+			event.TopATMID = atm.ATMID
+			event.Confidence = atm.Confidence
+			return event
+		}
+	}
+
+	if len(prediction.Top3ATMLocations) > 0 {
+		event.TopATMID = prediction.Top3ATMLocations[0].ATMID
+		event.Confidence = prediction.Top3ATMLocations[0].Confidence
+	}
+
+	return event
+}
+
+// emitPredictionEvent marshals a PredictionEvent and emits it under the given
+// name, wrapping any marshal failure so callers can surface it the same way
+// as other chaincode errors.
+func emitPredictionEvent(ctx contractapi.TransactionContextInterface, name string, prediction PredictionData) error {
+	payload, err := json.Marshal(topATMEvent(prediction))
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %v", name, err)
+	}
+
+	return ctx.GetStub().SetEvent(name, payload)
+}
+
+// PredictionsBatchEvent is the payload for EventPredictionsBatchStored/
+// EventPredictionsBatchUpserted, carrying every case ID the batch wrote so
+// a listener doesn't have to re-fetch the batch request to know what
+// changed.
+type PredictionsBatchEvent struct {
+	CaseIDs []string `json:"caseIds"`
+}
+
+// emitBatchEvent marshals a PredictionsBatchEvent and emits it under name.
+func emitBatchEvent(ctx contractapi.TransactionContextInterface, name string, caseIDs []string) error {
+	payload, err := json.Marshal(PredictionsBatchEvent{CaseIDs: caseIDs})
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %v", name, err)
+	}
+
+	return ctx.GetStub().SetEvent(name, payload)
+}
+
+// Roles recognized by requireRole. Each is asserted as a client identity
+// attribute (e.g. an investigator's enrollment certificate carries
+// attribute "investigator"="true"), not derived from the MSP ID alone,
+// since a single org can have members with different roles.
+const (
+	RoleInvestigator = "investigator"
+	RoleAnalyst      = "analyst"
+	RoleAuditor      = "auditor"
+)
+
+// configIndex namespaces Init's on-chain settings (the ACL map and the
+// case-management config) under a composite key instead of a plain world
+// -state key. GetAllPredictions/collectPage range over the entire world
+// -state keyspace and skip anything under this namespace (the
+// bank~city~caseId/topAtmId~caseId indexes stay out of that scan by living in
+// atmLocationsPDC instead) - otherwise a plain PutState(aclStateKey, ...) would show up as a bogus
+// record on the first page of every audit query.
+const configIndex = "config"
+
+// configStateKey builds the composite key a given config name is stored
+// under; see configIndex.
+func configStateKey(ctx contractapi.TransactionContextInterface, name string) (string, error) {
+	return ctx.GetStub().CreateCompositeKey(configIndex, []string{name})
+}
+
+// aclStateKey names the on-chain ACL map entry seeded by Init, keyed by MSP
+// ID.
+const aclStateKey = "ACL_CONFIG"
+
+// aclEntry lists the roles an org's members are permitted to hold, in
+// addition to whatever per-identity attributes they present. Deployments
+// that don't call Init fall back to attribute-only checks.
+type aclEntry struct {
+	MSPID string   `json:"mspId"`
+	Roles []string `json:"roles"`
+}
+
+// caseManagementConfigKey names the on-chain config entry (chaincode name +
+// channel) that requireOpenCase uses to reach the case-management
+// chaincode, seeded by Init alongside the ACL map.
+const caseManagementConfigKey = "CASE_MANAGEMENT_CONFIG"
+
+// caseManagementConfig names the chaincode/channel InvokeChaincode should
+// target to look up a case's status. Kept on-chain (rather than hardcoded)
+// so the same PredictionContract binary can be deployed against different
+// case-management deployments per channel/environment.
+type caseManagementConfig struct {
+	ChaincodeName string `json:"chaincodeName"`
+	Channel       string `json:"channel"`
+}
+
+// Init seeds the on-chain ACL map and the case-management chaincode config
+// from caller-supplied JSON. It must be invoked once after chaincode
+// instantiation/upgrade, before any of the role-gated methods below (or
+// StorePrediction/UpdatePrediction, which depend on the case-management
+// config) are called.
+func (s *PredictionContract) Init(ctx contractapi.TransactionContextInterface, aclJSON string, caseManagementJSON string) error {
+	var entries []aclEntry
+	if err := json.Unmarshal([]byte(aclJSON), &entries); err != nil {
+		return fmt.Errorf("failed to unmarshal ACL config: %v", err)
+	}
+
+	aclBytes, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ACL config: %v", err)
+	}
+
+	aclKey, err := configStateKey(ctx, aclStateKey)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.GetStub().PutState(aclKey, aclBytes); err != nil {
+		return err
+	}
+
+	var caseConfig caseManagementConfig
+	if err := json.Unmarshal([]byte(caseManagementJSON), &caseConfig); err != nil {
+		return fmt.Errorf("failed to unmarshal case management config: %v", err)
+	}
+
+	if caseConfig.ChaincodeName == "" || caseConfig.Channel == "" {
+		return fmt.Errorf("case management config requires both chaincodeName and channel")
+	}
+
+	caseConfigBytes, err := json.Marshal(caseConfig)
+	if err != nil {
+		return fmt.Errorf("failed to marshal case management config: %v", err)
+	}
+
+	caseConfigKey, err := configStateKey(ctx, caseManagementConfigKey)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(caseConfigKey, caseConfigBytes)
+}
+
+// getCaseManagementConfig reads back the config seeded by Init.
+func getCaseManagementConfig(ctx contractapi.TransactionContextInterface) (*caseManagementConfig, error) {
+	caseConfigKey, err := configStateKey(ctx, caseManagementConfigKey)
+	if err != nil {
+		return nil, err
+	}
+
+	configBytes, err := ctx.GetStub().GetState(caseConfigKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read case management config: %v", err)
+	}
+
+	if configBytes == nil {
+		return nil, fmt.Errorf("case management config is not set; call Init first")
+	}
+
+	var config caseManagementConfig
+	if err := json.Unmarshal(configBytes, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal case management config: %v", err)
+	}
+
+	return &config, nil
+}
+
+// requireOpenCase calls the case-management chaincode's GetCaseStatus and
+// rejects unless the case exists and its status is neither "closed" nor
+// "archived". StorePrediction and UpdatePrediction (including their batch
+// equivalents) call this so a prediction can never be filed against a case
+// that isn't actually open.
+func requireOpenCase(ctx contractapi.TransactionContextInterface, caseID string) error {
+	config, err := getCaseManagementConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	response := ctx.GetStub().InvokeChaincode(config.ChaincodeName, [][]byte{[]byte("GetCaseStatus"), []byte(caseID)}, config.Channel)
+	if response.Status != shim.OK {
+		return fmt.Errorf("case %s is not known to case management: %s", caseID, response.Message)
+	}
+
+	status := string(response.Payload)
+	if status == "closed" || status == "archived" {
+		return fmt.Errorf("case %s is %s and cannot accept new predictions", caseID, status)
+	}
+
+	return nil
+}
+
+// mspAllowsRole reports whether the ACL map seeded by Init permits mspID to
+// hold role. A deployment that never called Init (no ACL map in world
+// state) allows every role, falling back to the per-identity attribute
+// check alone.
+func mspAllowsRole(ctx contractapi.TransactionContextInterface, mspID string, role string) (bool, error) {
+	aclKey, err := configStateKey(ctx, aclStateKey)
+	if err != nil {
+		return false, err
+	}
+
+	aclBytes, err := ctx.GetStub().GetState(aclKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to read ACL config: %v", err)
+	}
+
+	if aclBytes == nil {
+		return true, nil
+	}
+
+	var entries []aclEntry
+	if err := json.Unmarshal(aclBytes, &entries); err != nil {
+		return false, fmt.Errorf("failed to unmarshal ACL config: %v", err)
+	}
+
+	for _, entry := range entries {
+		if entry.MSPID != mspID {
+			continue
+		}
+
+		for _, allowed := range entry.Roles {
+			if allowed == role {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+
+	return false, nil
+}
+
+// requireRole rejects the transaction unless the calling identity presents
+// the given role as a client identity attribute with value "true" AND the
+// caller's MSP is permitted to hold that role by the ACL map seeded by
+// Init. Attributes are granted per-identity by the CA at enrollment time
+// (e.g. `--attrs "investigator=true:ecert"`); the ACL map lets an operator
+// revoke a whole org's access to a role on-chain without touching
+// individual certs.
+//
+// assertAttributeValue and getMSPID are indirected so unit tests can
+// simulate a caller's identity attributes and MSP without constructing a
+// real enrollment certificate; production code always leaves these as
+// cid.AssertAttributeValue/cid.GetMSPID.
+var (
+	assertAttributeValue = cid.AssertAttributeValue
+	getMSPID             = cid.GetMSPID
+)
+
+func requireRole(ctx contractapi.TransactionContextInterface, role string) error {
+	if err := assertAttributeValue(ctx.GetStub(), role, "true"); err != nil {
+		return fmt.Errorf("access denied: caller does not have the %s role: %v", role, err)
+	}
+
+	mspID, err := getMSPID(ctx.GetStub())
+	if err != nil {
+		return fmt.Errorf("access denied: failed to resolve caller MSP: %v", err)
+	}
+
+	allowed, err := mspAllowsRole(ctx, mspID, role)
+	if err != nil {
+		return err
+	}
+
+	if !allowed {
+		return fmt.Errorf("access denied: MSP %s is not permitted the %s role", mspID, role)
+	}
+
+	return nil
 }
 
 // StorePrediction stores prediction data on the blockchain
 func (s *PredictionContract) StorePrediction(ctx contractapi.TransactionContextInterface, predictionJSON string) error {
+	if err := requireRole(ctx, RoleInvestigator); err != nil {
+		return err
+	}
+
 	var prediction PredictionData
 
 	err := json.Unmarshal([]byte(predictionJSON), &prediction)
@@ -86,18 +607,15 @@ func (s *PredictionContract) StorePrediction(ctx contractapi.TransactionContextI
 		return fmt.Errorf("prediction for case %s already exists", prediction.CaseID)
 	}
 
-	// Convert to JSON for storage
-	predictionBytes, err := json.Marshal(prediction)
-	if err != nil {
-		return fmt.Errorf("failed to marshal prediction: %v", err)
-	}
-
-	// Store with caseId as key
-	return ctx.GetStub().PutState(prediction.CaseID, predictionBytes)
+	return storeNewPrediction(ctx, prediction)
 }
 
 // UpdatePrediction updates existing prediction data
 func (s *PredictionContract) UpdatePrediction(ctx contractapi.TransactionContextInterface, predictionJSON string) error {
+	if err := requireRole(ctx, RoleInvestigator); err != nil {
+		return err
+	}
+
 	var prediction PredictionData
 
 	err := json.Unmarshal([]byte(predictionJSON), &prediction)
@@ -110,7 +628,8 @@ func (s *PredictionContract) UpdatePrediction(ctx contractapi.TransactionContext
 		return fmt.Errorf("caseId is required")
 	}
 
-	// Check if prediction exists
+	// Check if prediction exists, and load its current public/private halves
+	// so we can remove their now-stale secondary index entries below.
 	existingBytes, err := ctx.GetStub().GetState(prediction.CaseID)
 	if err != nil {
 		return fmt.Errorf("failed to read from world state: %v", err)
@@ -120,41 +639,544 @@ func (s *PredictionContract) UpdatePrediction(ctx contractapi.TransactionContext
 		return fmt.Errorf("prediction for case %s does not exist", prediction.CaseID)
 	}
 
-	// Update timestamp
+	var existingPublic PredictionPublic
+	if err := json.Unmarshal(existingBytes, &existingPublic); err != nil {
+		return fmt.Errorf("failed to unmarshal prediction: %v", err)
+	}
+
+	var existingPrivate PredictionPrivate
+	existingPrivateBytes, err := ctx.GetStub().GetPrivateData(atmLocationsPDC, prediction.CaseID)
+	if err != nil {
+		return fmt.Errorf("failed to read private prediction data: %v", err)
+	}
+
+	if existingPrivateBytes != nil {
+		if err := json.Unmarshal(existingPrivateBytes, &existingPrivate); err != nil {
+			return fmt.Errorf("failed to unmarshal private prediction data: %v", err)
+		}
+	}
+
+	return updateExistingPrediction(ctx, prediction, existingPublic, existingPrivate)
+}
+
+// updateExistingPrediction refreshes the timestamp, re-splits, and persists
+// prediction over an already-confirmed-to-exist case, replacing the old
+// secondary index entries (derived from existingPublic/existingPrivate)
+// with new ones. UpdatePrediction and upsertPrediction's update path share
+// this.
+func updateExistingPrediction(ctx contractapi.TransactionContextInterface, prediction PredictionData, existingPublic PredictionPublic, existingPrivate PredictionPrivate) error {
+	if err := requireOpenCase(ctx, prediction.CaseID); err != nil {
+		return err
+	}
+
 	prediction.Timestamp = time.Now().UTC().Format(time.RFC3339)
 
-	// Convert to JSON for storage
-	predictionBytes, err := json.Marshal(prediction)
+	// Split into the public record (world state) and the sensitive record
+	// (atmLocationsPDC), committing a hash of the latter into the former.
+	public, private, err := splitPrediction(prediction)
+	if err != nil {
+		return err
+	}
+
+	// Evidence links aren't part of the caller-supplied prediction payload;
+	// carry them over from the existing record so an update doesn't erase
+	// chain-of-custody history.
+	public.EvidenceLinks = existingPublic.EvidenceLinks
+
+	publicBytes, err := json.Marshal(public)
+	if err != nil {
+		return fmt.Errorf("failed to marshal prediction: %v", err)
+	}
+
+	privateBytes, err := json.Marshal(private)
 	if err != nil {
 		return fmt.Errorf("failed to marshal prediction: %v", err)
 	}
 
 	// Update state
-	return ctx.GetStub().PutState(prediction.CaseID, predictionBytes)
+	if err := ctx.GetStub().PutState(prediction.CaseID, publicBytes); err != nil {
+		return err
+	}
+
+	if err := ctx.GetStub().PutPrivateData(atmLocationsPDC, prediction.CaseID, privateBytes); err != nil {
+		return fmt.Errorf("failed to write private prediction data: %v", err)
+	}
+
+	if err := deleteSecondaryIndexes(ctx, existingPublic, existingPrivate); err != nil {
+		return err
+	}
+
+	if err := putSecondaryIndexes(ctx, public, private); err != nil {
+		return err
+	}
+
+	return emitPredictionEvent(ctx, EventPredictionUpdated, prediction)
+}
+
+// MaxBatchSize bounds StorePredictionsBatch and UpsertPredictionsBatch so a
+// single transaction can't grow large enough to blow the block size / MVCC
+// read-write set limits.
+const MaxBatchSize = 500
+
+// BatchItemResult reports the outcome of a single prediction within a
+// StorePredictionsBatch/UpsertPredictionsBatch call, so a client can retry
+// only the cases that failed instead of resubmitting the whole batch.
+type BatchItemResult struct {
+	CaseID  string `json:"caseId"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// validateBatchInput unmarshals a batch request and checks its size, but
+// does not touch the ledger.
+func validateBatchInput(predictionsJSON string) ([]PredictionData, error) {
+	var predictions []PredictionData
+	if err := json.Unmarshal([]byte(predictionsJSON), &predictions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal batch: %v", err)
+	}
+
+	if len(predictions) == 0 {
+		return nil, fmt.Errorf("batch must contain at least one prediction")
+	}
+
+	if len(predictions) > MaxBatchSize {
+		return nil, fmt.Errorf("batch of %d exceeds MaxBatchSize of %d", len(predictions), MaxBatchSize)
+	}
+
+	return predictions, nil
+}
+
+// StorePredictionsBatch validates and then stores every prediction in
+// predictionsJSON (a JSON array of PredictionData) as new cases. Every item
+// is validated - required fields, and that its caseId doesn't already exist
+// or repeat elsewhere in the batch - before any PutState call is made, so a
+// single bad item fails the whole batch atomically rather than partially
+// committing. The returned []BatchItemResult reports every item's validation
+// outcome whether validation passes or fails, so a client can tell which
+// caseIds to retry; an error during the write phase (such as requireOpenCase
+// rejecting a closed case) returns no results.
+func (s *PredictionContract) StorePredictionsBatch(ctx contractapi.TransactionContextInterface, predictionsJSON string) ([]BatchItemResult, error) {
+	if err := requireRole(ctx, RoleInvestigator); err != nil {
+		return nil, err
+	}
+
+	predictions, err := validateBatchInput(predictionsJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchItemResult, len(predictions))
+	seen := make(map[string]bool, len(predictions))
+	anyFailed := false
+
+	for i, prediction := range predictions {
+		results[i].CaseID = prediction.CaseID
+
+		switch {
+		case prediction.CaseID == "":
+			results[i].Error = "caseId is required"
+		case len(prediction.Top3ATMLocations) == 0:
+			results[i].Error = "at least one ATM location is required"
+		case seen[prediction.CaseID]:
+			results[i].Error = fmt.Sprintf("caseId %s is duplicated within the batch", prediction.CaseID)
+		default:
+			existingBytes, err := ctx.GetStub().GetState(prediction.CaseID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read from world state: %v", err)
+			}
+
+			if existingBytes != nil {
+				results[i].Error = fmt.Sprintf("prediction for case %s already exists", prediction.CaseID)
+			}
+		}
+
+		if results[i].Error != "" {
+			anyFailed = true
+			continue
+		}
+
+		seen[prediction.CaseID] = true
+		results[i].Success = true
+	}
+
+	if anyFailed {
+		return results, fmt.Errorf("batch rejected: one or more items failed validation")
+	}
+
+	caseIDs := make([]string, 0, len(predictions))
+	for _, prediction := range predictions {
+		if err := storeNewPrediction(ctx, prediction); err != nil {
+			return nil, err
+		}
+
+		caseIDs = append(caseIDs, prediction.CaseID)
+	}
+
+	if err := emitBatchEvent(ctx, EventPredictionsBatchStored, caseIDs); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// UpsertPredictionsBatch validates and then stores or updates every
+// prediction in predictionsJSON (a JSON array of PredictionData), inserting
+// caseIds that don't yet exist and updating (with a refreshed timestamp)
+// those that do. As with StorePredictionsBatch, every item is validated
+// before any PutState call is made.
+func (s *PredictionContract) UpsertPredictionsBatch(ctx contractapi.TransactionContextInterface, predictionsJSON string) ([]BatchItemResult, error) {
+	if err := requireRole(ctx, RoleInvestigator); err != nil {
+		return nil, err
+	}
+
+	predictions, err := validateBatchInput(predictionsJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchItemResult, len(predictions))
+	seen := make(map[string]bool, len(predictions))
+	anyFailed := false
+
+	for i, prediction := range predictions {
+		results[i].CaseID = prediction.CaseID
+
+		switch {
+		case prediction.CaseID == "":
+			results[i].Error = "caseId is required"
+		case len(prediction.Top3ATMLocations) == 0:
+			results[i].Error = "at least one ATM location is required"
+		case seen[prediction.CaseID]:
+			results[i].Error = fmt.Sprintf("caseId %s is duplicated within the batch", prediction.CaseID)
+		}
+
+		if results[i].Error != "" {
+			anyFailed = true
+			continue
+		}
+
+		seen[prediction.CaseID] = true
+		results[i].Success = true
+	}
+
+	if anyFailed {
+		return results, fmt.Errorf("batch rejected: one or more items failed validation")
+	}
+
+	caseIDs := make([]string, 0, len(predictions))
+	for _, prediction := range predictions {
+		if err := upsertPrediction(ctx, prediction); err != nil {
+			return nil, err
+		}
+
+		caseIDs = append(caseIDs, prediction.CaseID)
+	}
+
+	if err := emitBatchEvent(ctx, EventPredictionsBatchUpserted, caseIDs); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// storeNewPrediction persists a brand-new prediction - public record,
+// private record, and secondary indexes - and emits PredictionStored. It
+// assumes the caller has already checked that prediction.CaseID doesn't yet
+// exist; StorePrediction and StorePredictionsBatch share this.
+func storeNewPrediction(ctx contractapi.TransactionContextInterface, prediction PredictionData) error {
+	if err := requireOpenCase(ctx, prediction.CaseID); err != nil {
+		return err
+	}
+
+	public, private, err := splitPrediction(prediction)
+	if err != nil {
+		return err
+	}
+
+	publicBytes, err := json.Marshal(public)
+	if err != nil {
+		return fmt.Errorf("failed to marshal prediction: %v", err)
+	}
+
+	privateBytes, err := json.Marshal(private)
+	if err != nil {
+		return fmt.Errorf("failed to marshal prediction: %v", err)
+	}
+
+	if err := ctx.GetStub().PutState(prediction.CaseID, publicBytes); err != nil {
+		return err
+	}
+
+	if err := ctx.GetStub().PutPrivateData(atmLocationsPDC, prediction.CaseID, privateBytes); err != nil {
+		return fmt.Errorf("failed to write private prediction data: %v", err)
+	}
+
+	if err := putSecondaryIndexes(ctx, public, private); err != nil {
+		return err
+	}
+
+	return emitPredictionEvent(ctx, EventPredictionStored, prediction)
+}
+
+// upsertPrediction stores prediction as a new case, or updates it (with a
+// refreshed timestamp and reindexed secondary keys) if prediction.CaseID
+// already exists. UpsertPredictionsBatch uses this for each item.
+func upsertPrediction(ctx contractapi.TransactionContextInterface, prediction PredictionData) error {
+	existingBytes, err := ctx.GetStub().GetState(prediction.CaseID)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	if existingBytes == nil {
+		return storeNewPrediction(ctx, prediction)
+	}
+
+	var existingPublic PredictionPublic
+	if err := json.Unmarshal(existingBytes, &existingPublic); err != nil {
+		return fmt.Errorf("failed to unmarshal prediction: %v", err)
+	}
+
+	var existingPrivate PredictionPrivate
+	existingPrivateBytes, err := ctx.GetStub().GetPrivateData(atmLocationsPDC, prediction.CaseID)
+	if err != nil {
+		return fmt.Errorf("failed to read private prediction data: %v", err)
+	}
+
+	if existingPrivateBytes != nil {
+		if err := json.Unmarshal(existingPrivateBytes, &existingPrivate); err != nil {
+			return fmt.Errorf("failed to unmarshal private prediction data: %v", err)
+		}
+	}
+
+	return updateExistingPrediction(ctx, prediction, existingPublic, existingPrivate)
+}
+
+// InvalidatePrediction marks an existing prediction as invalidated, leaving
+// the underlying record in world state (for history/audit purposes) but
+// emitting a PredictionInvalidated event so downstream consumers stop
+// treating it as the active prediction for the case.
+func (s *PredictionContract) InvalidatePrediction(ctx contractapi.TransactionContextInterface, caseID string) error {
+	if err := requireRole(ctx, RoleInvestigator); err != nil {
+		return err
+	}
+
+	// Use the role-skipping helper, not the public GetPrediction, since that
+	// gates on RoleAnalyst and would require investigators invalidating a
+	// case to also hold the analyst attribute.
+	prediction, err := mergedPrediction(ctx, caseID)
+	if err != nil {
+		return err
+	}
+
+	return emitPredictionEvent(ctx, EventPredictionInvalidated, *prediction)
+}
+
+// LinkPredictionToEvidence records that evidenceHash - a reference tracked
+// by another chaincode - applies to caseID's prediction, appending it to
+// the prediction's EvidenceLinks. Storing this alongside the prediction
+// (rather than requiring a separate lookup against the evidence chaincode)
+// lets an investigator see the full chain of custody through a single
+// GetPrediction call.
+func (s *PredictionContract) LinkPredictionToEvidence(ctx contractapi.TransactionContextInterface, caseID string, evidenceHash string) error {
+	if err := requireRole(ctx, RoleInvestigator); err != nil {
+		return err
+	}
+
+	if evidenceHash == "" {
+		return fmt.Errorf("evidenceHash is required")
+	}
+
+	if err := requireOpenCase(ctx, caseID); err != nil {
+		return err
+	}
+
+	publicBytes, err := ctx.GetStub().GetState(caseID)
+	if err != nil {
+		return fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	if publicBytes == nil {
+		return fmt.Errorf("prediction for case %s does not exist", caseID)
+	}
+
+	var public PredictionPublic
+	if err := json.Unmarshal(publicBytes, &public); err != nil {
+		return fmt.Errorf("failed to unmarshal prediction: %v", err)
+	}
+
+	linkedBy, err := cid.GetMSPID(ctx.GetStub())
+	if err != nil {
+		return fmt.Errorf("failed to resolve caller MSP ID: %v", err)
+	}
+
+	public.EvidenceLinks = append(public.EvidenceLinks, EvidenceLink{
+		EvidenceHash: evidenceHash,
+		LinkedBy:     linkedBy,
+		LinkedAt:     time.Now().UTC().Format(time.RFC3339),
+	})
+
+	updatedBytes, err := json.Marshal(public)
+	if err != nil {
+		return fmt.Errorf("failed to marshal prediction: %v", err)
+	}
+
+	return ctx.GetStub().PutState(caseID, updatedBytes)
 }
 
 // GetPrediction retrieves prediction data by caseId
 func (s *PredictionContract) GetPrediction(ctx contractapi.TransactionContextInterface, caseID string) (*PredictionData, error) {
-	predictionBytes, err := ctx.GetStub().GetState(caseID)
+	if err := requireRole(ctx, RoleAnalyst); err != nil {
+		return nil, err
+	}
+
+	return mergedPrediction(ctx, caseID)
+}
+
+// mergedPrediction reads a prediction's public record from world state and
+// merges in its private record from atmLocationsPDC, without the per-call
+// role check that GetPrediction and the QueryPredictionsBy* methods perform
+// themselves before calling this.
+func mergedPrediction(ctx contractapi.TransactionContextInterface, caseID string) (*PredictionData, error) {
+	publicBytes, err := ctx.GetStub().GetState(caseID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read from world state: %v", err)
 	}
 
-	if predictionBytes == nil {
+	if publicBytes == nil {
 		return nil, fmt.Errorf("prediction for case %s does not exist", caseID)
 	}
 
-	var prediction PredictionData
-	err = json.Unmarshal(predictionBytes, &prediction)
-	if err != nil {
+	var public PredictionPublic
+	if err := json.Unmarshal(publicBytes, &public); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal prediction: %v", err)
 	}
 
+	// Merge in the sensitive half from atmLocationsPDC if our org is a member
+	// of the collection; GetPrivateData returns a nil payload (no error) for
+	// a key the caller's org can't see, in which case the sensitive fields
+	// of the merged record are simply left empty.
+	var private PredictionPrivate
+	privateBytes, err := ctx.GetStub().GetPrivateData(atmLocationsPDC, caseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private prediction data: %v", err)
+	}
+
+	if privateBytes != nil {
+		if err := json.Unmarshal(privateBytes, &private); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal private prediction data: %v", err)
+		}
+	}
+
+	prediction := mergePrediction(public, private)
 	return &prediction, nil
 }
 
-// QueryPredictionsByDateRange queries predictions within a date range
-func (s *PredictionContract) QueryPredictionsByDateRange(ctx contractapi.TransactionContextInterface, startDate string, endDate string) ([]*PredictionData, error) {
+// VerifyPredictionIntegrity recomputes the hash of the private prediction
+// record for caseID and checks it against the commitment stored in the
+// public record, returning true only if they match (and both halves exist
+// and are readable by the caller). This lets any org with access to
+// atmLocationsPDC confirm the private data on file hasn't been tampered
+// with, without exposing that data to orgs that aren't collection members.
+func (s *PredictionContract) VerifyPredictionIntegrity(ctx contractapi.TransactionContextInterface, caseID string) (bool, error) {
+	if err := requireRole(ctx, RoleAuditor); err != nil {
+		return false, err
+	}
+
+	publicBytes, err := ctx.GetStub().GetState(caseID)
+	if err != nil {
+		return false, fmt.Errorf("failed to read from world state: %v", err)
+	}
+
+	if publicBytes == nil {
+		return false, fmt.Errorf("prediction for case %s does not exist", caseID)
+	}
+
+	var public PredictionPublic
+	if err := json.Unmarshal(publicBytes, &public); err != nil {
+		return false, fmt.Errorf("failed to unmarshal prediction: %v", err)
+	}
+
+	privateBytes, err := ctx.GetStub().GetPrivateData(atmLocationsPDC, caseID)
+	if err != nil {
+		return false, fmt.Errorf("failed to read private prediction data: %v", err)
+	}
+
+	if privateBytes == nil {
+		return false, fmt.Errorf("private prediction data for case %s is not accessible to this org", caseID)
+	}
+
+	var private PredictionPrivate
+	if err := json.Unmarshal(privateBytes, &private); err != nil {
+		return false, fmt.Errorf("failed to unmarshal private prediction data: %v", err)
+	}
+
+	recomputedHash, err := hashPrivatePrediction(private)
+	if err != nil {
+		return false, err
+	}
+
+	return recomputedHash == public.SensitiveDataHash, nil
+}
+
+// PagedPredictions is the result shape for every paginated query method:
+// the page of results, the bookmark to pass back in as the next page's
+// starting point, and how many ledger records the peer actually scanned to
+// produce this page (as reported by Fabric's pagination metadata).
+type PagedPredictions struct {
+	Results        []*PredictionPublic `json:"results"`
+	Bookmark       string              `json:"bookmark"`
+	FetchedRecords int32               `json:"fetchedRecords"`
+}
+
+// compositeKeyNamespace is the leading byte Fabric prefixes every
+// CreateCompositeKey-generated key with, so it sorts before all plain keys
+// in a world-state range scan. collectPage uses it to recognize and skip
+// composite-key entries (the configIndex settings, and any future index)
+// that a full-range GetAllPredictions scan would otherwise also return.
+const compositeKeyNamespace = "\x00"
+
+// collectPage drains a paginated iterator into a PagedPredictions, skipping
+// composite-key entries (see compositeKeyNamespace) and wrapping the
+// per-record unmarshal error the same way the rest of this file does.
+func collectPage(resultsIterator shim.StateQueryIteratorInterface, metadata *peer.QueryResponseMetadata) (*PagedPredictions, error) {
+	defer resultsIterator.Close()
+
+	var predictions []*PredictionPublic
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasPrefix(queryResponse.Key, compositeKeyNamespace) {
+			continue
+		}
+
+		var prediction PredictionPublic
+		if err := json.Unmarshal(queryResponse.Value, &prediction); err != nil {
+			return nil, err
+		}
+
+		predictions = append(predictions, &prediction)
+	}
+
+	return &PagedPredictions{
+		Results:        predictions,
+		Bookmark:       metadata.GetBookmark(),
+		FetchedRecords: metadata.GetFetchedRecordsCount(),
+	}, nil
+}
+
+// QueryPredictionsByDateRange queries predictions within a date range, one
+// page at a time. Results only carry the public portion of each prediction
+// (see PredictionPublic) since this scans world state directly rather than
+// merging in atmLocationsPDC per result; call GetPrediction for a single
+// case's full, merged record.
+func (s *PredictionContract) QueryPredictionsByDateRange(ctx contractapi.TransactionContextInterface, startDate string, endDate string, pageSize int32, bookmark string) (*PagedPredictions, error) {
+	if err := requireRole(ctx, RoleAuditor); err != nil {
+		return nil, err
+	}
+
 	queryString := fmt.Sprintf(`{
 		"selector": {
 			"timestamp": {
@@ -164,74 +1186,221 @@ func (s *PredictionContract) QueryPredictionsByDateRange(ctx contractapi.Transac
 		}
 	}`, startDate, endDate)
 
-	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(queryString, pageSize, bookmark)
 	if err != nil {
 		return nil, err
 	}
-	defer resultsIterator.Close()
 
-	var predictions []*PredictionData
-	for resultsIterator.HasNext() {
-		queryResponse, err := resultsIterator.Next()
-		if err != nil {
-			return nil, err
+	return collectPage(resultsIterator, metadata)
+}
+
+// GetAllPredictions returns the public portion of all predictions, one page
+// at a time (use with caution in production). See QueryPredictionsByDateRange
+// for why the sensitive atmLocationsPDC fields aren't included here.
+func (s *PredictionContract) GetAllPredictions(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*PagedPredictions, error) {
+	if err := requireRole(ctx, RoleAuditor); err != nil {
+		return nil, err
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination("", "", pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectPage(resultsIterator, metadata)
+}
+
+// queryableFields allowlists the world-state (PredictionPublic) fields a
+// caller-supplied Mango selector may reference in QueryPredictions, so a
+// selector can't filter on arbitrary fields. This package defines no CouchDB
+// indexes for these fields.
+var queryableFields = map[string]bool{
+	"caseId":                true,
+	"timestamp":             true,
+	"overallConfidence":     true,
+	"ranks":                 true,
+	"model_info.model_name": true,
+	"model_info.version":    true,
+	"model_info.mode":       true,
+}
+
+// validateSelector rejects a Mango selector that references any field
+// outside queryableFields. It only inspects the top level and the common
+// operator wrappers ($and/$or/$nor), which is enough to stop the
+// unindexed-scan case this method guards against without reimplementing a
+// full Mango parser.
+func validateSelector(selector map[string]interface{}) error {
+	for field, value := range selector {
+		if field == "$and" || field == "$or" || field == "$nor" {
+			clauses, ok := value.([]interface{})
+			if !ok {
+				return fmt.Errorf("selector operator %s must be an array", field)
+			}
+
+			for _, clause := range clauses {
+				clauseMap, ok := clause.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("selector operator %s must contain objects", field)
+				}
+
+				if err := validateSelector(clauseMap); err != nil {
+					return err
+				}
+			}
+
+			continue
 		}
 
-		var prediction PredictionData
-		err = json.Unmarshal(queryResponse.Value, &prediction)
-		if err != nil {
-			return nil, err
+		if !queryableFields[field] {
+			return fmt.Errorf("field %s is not queryable", field)
 		}
+	}
 
-		predictions = append(predictions, &prediction)
+	return nil
+}
+
+// QueryPredictions runs a caller-supplied Mango selector against world
+// state, paginated. The selector's fields are checked against
+// queryableFields first, so a client can only filter on those fields.
+func (s *PredictionContract) QueryPredictions(ctx contractapi.TransactionContextInterface, selectorJSON string, pageSize int32, bookmark string) (*PagedPredictions, error) {
+	if err := requireRole(ctx, RoleAuditor); err != nil {
+		return nil, err
 	}
 
-	return predictions, nil
+	var selector map[string]interface{}
+	if err := json.Unmarshal([]byte(selectorJSON), &selector); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal selector: %v", err)
+	}
+
+	if err := validateSelector(selector); err != nil {
+		return nil, err
+	}
+
+	queryString, err := json.Marshal(map[string]interface{}{"selector": selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal selector: %v", err)
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetQueryResultWithPagination(string(queryString), pageSize, bookmark)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectPage(resultsIterator, metadata)
 }
 
-// GetAllPredictions returns all predictions (use with caution in production)
-func (s *PredictionContract) GetAllPredictions(ctx contractapi.TransactionContextInterface) ([]*PredictionData, error) {
-	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+// caseIDsFromCompositeIndex ranges over every key in a private-data
+// composite-key index and extracts the trailing caseId attribute from each.
+func caseIDsFromCompositeIndex(ctx contractapi.TransactionContextInterface, objectType string, attributes []string) ([]string, error) {
+	iterator, err := ctx.GetStub().GetPrivateDataByPartialCompositeKey(atmLocationsPDC, objectType, attributes)
 	if err != nil {
 		return nil, err
 	}
-	defer resultsIterator.Close()
+	defer iterator.Close()
 
-	var predictions []*PredictionData
-	for resultsIterator.HasNext() {
-		queryResponse, err := resultsIterator.Next()
+	var caseIDs []string
+	for iterator.HasNext() {
+		result, err := iterator.Next()
 		if err != nil {
 			return nil, err
 		}
 
-		var prediction PredictionData
-		err = json.Unmarshal(queryResponse.Value, &prediction)
+		_, keyParts, err := ctx.GetStub().SplitCompositeKey(result.Key)
 		if err != nil {
 			return nil, err
 		}
 
-		predictions = append(predictions, &prediction)
+		caseIDs = append(caseIDs, keyParts[len(keyParts)-1])
+	}
+
+	return caseIDs, nil
+}
+
+// predictionsForCaseIDs merges and fetches the full PredictionData for each
+// caseID, in the style of GetPrediction, skipping the per-call role check
+// since the caller already performed it.
+func predictionsForCaseIDs(ctx contractapi.TransactionContextInterface, caseIDs []string) ([]*PredictionData, error) {
+	predictions := make([]*PredictionData, 0, len(caseIDs))
+	for _, caseID := range caseIDs {
+		prediction, err := mergedPrediction(ctx, caseID)
+		if err != nil {
+			return nil, err
+		}
+
+		predictions = append(predictions, prediction)
 	}
 
 	return predictions, nil
 }
 
-// GetPredictionHistory returns the history of a specific prediction
-func (s *PredictionContract) GetPredictionHistory(ctx contractapi.TransactionContextInterface, caseID string) ([]*PredictionData, error) {
+// QueryPredictionsByBank returns every prediction whose rank-1 ATM belongs
+// to bank, via the bank~city~caseId private-data index.
+func (s *PredictionContract) QueryPredictionsByBank(ctx contractapi.TransactionContextInterface, bank string) ([]*PredictionData, error) {
+	if err := requireRole(ctx, RoleAnalyst); err != nil {
+		return nil, err
+	}
+
+	caseIDs, err := caseIDsFromCompositeIndex(ctx, bankCityIndex, []string{bank})
+	if err != nil {
+		return nil, err
+	}
+
+	return predictionsForCaseIDs(ctx, caseIDs)
+}
+
+// QueryPredictionsByCity returns every prediction whose rank-1 ATM is in
+// bank/city, via the bank~city~caseId private-data index.
+func (s *PredictionContract) QueryPredictionsByCity(ctx contractapi.TransactionContextInterface, bank string, city string) ([]*PredictionData, error) {
+	if err := requireRole(ctx, RoleAnalyst); err != nil {
+		return nil, err
+	}
+
+	caseIDs, err := caseIDsFromCompositeIndex(ctx, bankCityIndex, []string{bank, city})
+	if err != nil {
+		return nil, err
+	}
+
+	return predictionsForCaseIDs(ctx, caseIDs)
+}
+
+// QueryPredictionsByATM returns every prediction whose rank-1 ATM is atmID,
+// via the topAtmId~caseId private-data index.
+func (s *PredictionContract) QueryPredictionsByATM(ctx contractapi.TransactionContextInterface, atmID string) ([]*PredictionData, error) {
+	if err := requireRole(ctx, RoleAnalyst); err != nil {
+		return nil, err
+	}
+
+	caseIDs, err := caseIDsFromCompositeIndex(ctx, topATMIndex, []string{atmID})
+	if err != nil {
+		return nil, err
+	}
+
+	return predictionsForCaseIDs(ctx, caseIDs)
+}
+
+// GetPredictionHistory returns the world-state history of a specific
+// prediction's public record. The sensitive atmLocationsPDC side does not
+// carry history in the same way (private data collections don't version
+// across organizations in the same manner as world state).
+func (s *PredictionContract) GetPredictionHistory(ctx contractapi.TransactionContextInterface, caseID string) ([]*PredictionPublic, error) {
+	if err := requireRole(ctx, RoleAuditor); err != nil {
+		return nil, err
+	}
+
 	historyIterator, err := ctx.GetStub().GetHistoryForKey(caseID)
 	if err != nil {
 		return nil, err
 	}
 	defer historyIterator.Close()
 
-	var history []*PredictionData
+	var history []*PredictionPublic
 	for historyIterator.HasNext() {
 		historyResponse, err := historyIterator.Next()
 		if err != nil {
 			return nil, err
 		}
 
-		var prediction PredictionData
+		var prediction PredictionPublic
 		err = json.Unmarshal(historyResponse.Value, &prediction)
 		if err != nil {
 			return nil, err
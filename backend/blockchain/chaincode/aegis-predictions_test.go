@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/stretchr/testify/require"
+)
+
+// testMSPID is the MSP withRole simulates the caller as belonging to.
+const testMSPID = "InvestigatorMSP"
+
+// withRole temporarily replaces assertAttributeValue and getMSPID so
+// requireRole sees the caller as holding exactly the given roles under
+// testMSPID, restoring the real cid-backed checks afterwards.
+func withRole(t *testing.T, roles ...string) {
+	t.Helper()
+
+	held := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		held[role] = true
+	}
+
+	originalAttr := assertAttributeValue
+	assertAttributeValue = func(_ cid.ChaincodeStubInterface, attrName string, _ string) error {
+		if !held[attrName] {
+			return fmt.Errorf("attribute %s not present", attrName)
+		}
+		return nil
+	}
+	t.Cleanup(func() { assertAttributeValue = originalAttr })
+
+	originalMSP := getMSPID
+	getMSPID = func(_ cid.ChaincodeStubInterface) (string, error) {
+		return testMSPID, nil
+	}
+	t.Cleanup(func() { getMSPID = originalMSP })
+}
+
+func newTestContext() (*contractapi.TransactionContext, *shimtest.MockStub) {
+	stub := shimtest.NewMockStub("prediction", nil)
+	// MockStub rejects PutState outside a transaction, which Init needs.
+	stub.MockTransactionStart("test-tx")
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(stub)
+	return ctx, stub
+}
+
+func TestRequireRole_AllowsWhenAttributePresent(t *testing.T) {
+	ctx, _ := newTestContext()
+	withRole(t, RoleInvestigator)
+
+	require.NoError(t, requireRole(ctx, RoleInvestigator))
+}
+
+func TestRequireRole_DeniesWhenAttributeMissing(t *testing.T) {
+	ctx, _ := newTestContext()
+	withRole(t, RoleAnalyst)
+
+	err := requireRole(ctx, RoleInvestigator)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "access denied")
+}
+
+func TestStorePrediction_RequiresInvestigatorRole(t *testing.T) {
+	ctx, _ := newTestContext()
+	withRole(t, RoleAnalyst)
+
+	contract := &PredictionContract{}
+	err := contract.StorePrediction(ctx, `{"caseId":"case-1","top3AtmLocations":[{"rank":1,"atm_id":"atm-1"}]}`)
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "access denied")
+}
+
+func TestGetPrediction_RequiresAnalystRole(t *testing.T) {
+	ctx, _ := newTestContext()
+	withRole(t, RoleInvestigator)
+
+	contract := &PredictionContract{}
+	_, err := contract.GetPrediction(ctx, "case-1")
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "access denied")
+}
+
+func TestGetAllPredictions_RequiresAuditorRole(t *testing.T) {
+	ctx, _ := newTestContext()
+	withRole(t, RoleInvestigator, RoleAnalyst)
+
+	contract := &PredictionContract{}
+	_, err := contract.GetAllPredictions(ctx, 10, "")
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "access denied")
+}
+
+func TestRequireRole_AllowsWhenACLGrantsMSP(t *testing.T) {
+	ctx, _ := newTestContext()
+	withRole(t, RoleInvestigator)
+
+	contract := &PredictionContract{}
+	aclJSON := fmt.Sprintf(`[{"mspId":%q,"roles":["investigator"]}]`, testMSPID)
+	require.NoError(t, contract.Init(ctx, aclJSON, `{"chaincodeName":"caseManagement","channel":"aegis-channel"}`))
+
+	require.NoError(t, requireRole(ctx, RoleInvestigator))
+}
+
+func TestRequireRole_DeniesWhenACLOmitsRoleForMSP(t *testing.T) {
+	ctx, _ := newTestContext()
+	withRole(t, RoleInvestigator)
+
+	contract := &PredictionContract{}
+	aclJSON := fmt.Sprintf(`[{"mspId":%q,"roles":["analyst"]}]`, testMSPID)
+	require.NoError(t, contract.Init(ctx, aclJSON, `{"chaincodeName":"caseManagement","channel":"aegis-channel"}`))
+
+	err := requireRole(ctx, RoleInvestigator)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "access denied")
+}
+
+func TestInit_SeedsACLMap(t *testing.T) {
+	ctx, stub := newTestContext()
+
+	contract := &PredictionContract{}
+	aclJSON := `[{"mspId":"InvestigatorMSP","roles":["investigator"]}]`
+	caseManagementJSON := `{"chaincodeName":"caseManagement","channel":"aegis-channel"}`
+
+	require.NoError(t, contract.Init(ctx, aclJSON, caseManagementJSON))
+
+	aclKey, err := stub.CreateCompositeKey(configIndex, []string{aclStateKey})
+	require.NoError(t, err)
+
+	storedACL, err := stub.GetState(aclKey)
+	require.NoError(t, err)
+
+	var entries []aclEntry
+	require.NoError(t, json.Unmarshal(storedACL, &entries))
+	require.Len(t, entries, 1)
+	require.Equal(t, "InvestigatorMSP", entries[0].MSPID)
+}